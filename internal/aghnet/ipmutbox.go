@@ -0,0 +1,38 @@
+package aghnet
+
+import (
+	"net"
+	"sync"
+)
+
+// IPMutFunc mutates ip in place, for example to anonymize a client address
+// before it's logged or displayed.
+type IPMutFunc func(ip net.IP)
+
+// IPMutBox is a concurrency-safe holder for the client-IP anonymization
+// function currently in effect.  The zero IPMutBox has a no-op function
+// loaded.
+type IPMutBox struct {
+	mu sync.RWMutex
+	f  IPMutFunc
+}
+
+// Load returns the current anonymization function.  It never returns nil.
+func (b *IPMutBox) Load() (f IPMutFunc) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.f == nil {
+		return func(net.IP) {}
+	}
+
+	return b.f
+}
+
+// Store sets the anonymization function used by subsequent Load calls.
+func (b *IPMutBox) Store(f IPMutFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.f = f
+}
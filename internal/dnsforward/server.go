@@ -0,0 +1,81 @@
+package dnsforward
+
+import (
+	"sync"
+	"time"
+
+	"github.com/AdguardTeam/AdGuardHome/internal/aghnet"
+	"github.com/AdguardTeam/AdGuardHome/internal/filtering"
+	"github.com/AdguardTeam/AdGuardHome/internal/querylog"
+	"github.com/AdguardTeam/AdGuardHome/internal/stats"
+	"github.com/AdguardTeam/dnsproxy/proxy"
+)
+
+// ServerConfig is the network-facing part of the DNS server's
+// configuration.
+type ServerConfig struct {
+	// RefuseAny, if true, makes the server neither answer nor log queries
+	// of type ANY.
+	RefuseAny bool
+
+	// AllowZoneTransfer enables serving AXFR/IXFR requests to clients that
+	// match ZoneTransferACL.  Zone transfers are refused unconditionally
+	// unless this is set.
+	AllowZoneTransfer bool
+
+	// ZoneTransferACL is the set of CIDRs allowed to request zone
+	// transfers when AllowZoneTransfer is set.  An empty ACL refuses
+	// every client.
+	ZoneTransferACL []string
+
+	// ZoneTransferUpstream is the address of the authoritative server
+	// this instance transfers zones from on a permitted client's behalf.
+	ZoneTransferUpstream string
+}
+
+// Server resolves, filters, logs, and records statistics for incoming DNS
+// requests.
+type Server struct {
+	conf ServerConfig
+
+	// serverLock guards queryLog and stats against being swapped out from
+	// under processQueryLogsAndStats while the proxy server is shutting
+	// down.
+	serverLock sync.RWMutex
+
+	anonymizer *aghnet.IPMutBox
+
+	queryLog querylog.QueryLog
+	stats    stats.Interface
+}
+
+// dnsContext carries the state of a single request as it moves through the
+// server's chain of processing steps.
+type dnsContext struct {
+	proxyCtx  *proxy.DNSContext
+	startTime time.Time
+	result    *filtering.Result
+	origResp  []byte
+	clientID  string
+
+	// zoneTransferRefused is set by processZoneTransfer when an AXFR/IXFR
+	// request was turned down over UDP or by the ACL, so that updateStats
+	// can tell a refused transfer from one that was passed on to the
+	// upstream.  It stays false if that upstream transfer then fails.
+	zoneTransferRefused bool
+}
+
+// resultCode is the outcome of a single step in the server's request
+// processing chain.
+type resultCode int
+
+// Processing chain outcomes.
+const (
+	// resultCodeSuccess means processing should continue to the next step.
+	resultCodeSuccess resultCode = iota + 1
+
+	// resultCodeFinish means a step has already produced the final
+	// response; the rest of the chain, including upstream forwarding,
+	// must be skipped.
+	resultCodeFinish
+)
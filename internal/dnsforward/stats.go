@@ -61,7 +61,14 @@ func (s *Server) processQueryLogsAndStats(ctx *dnsContext) (rc resultCode) {
 		case proxy.ProtoDNSCrypt:
 			p.ClientProto = querylog.ClientProtoDNSCrypt
 		default:
-			// Consider this a plain DNS-over-UDP or DNS-over-TCP request.
+			// This is a plain DNS-over-UDP or DNS-over-TCP request.  Tell the
+			// two apart the same way the DoH handler tells a TCP listener
+			// from a UDP one: by the concrete type of the remote address.
+			if _, ok := pctx.Addr.(*net.TCPAddr); ok {
+				p.ClientProto = querylog.ClientProtoPlainTCP
+			} else {
+				p.ClientProto = querylog.ClientProtoPlainUDP
+			}
 		}
 
 		if pctx.Upstream != nil {
@@ -71,6 +78,9 @@ func (s *Server) processQueryLogsAndStats(ctx *dnsContext) (rc resultCode) {
 			p.Cached = true
 		}
 
+		// s.queryLog.Add also forwards the query and, once resolved, the
+		// response to any configured querylog.Tapper (see
+		// internal/dnstap), so there's no separate dnsTap call here.
 		s.queryLog.Add(p)
 	}
 
@@ -103,17 +113,29 @@ func (s *Server) updateStats(
 	e.Time = uint32(elapsed / 1000)
 	e.Result = stats.RNotFiltered
 
-	switch res.Reason {
-	case filtering.FilteredSafeBrowsing:
-		e.Result = stats.RSafeBrowsing
-	case filtering.FilteredParental:
-		e.Result = stats.RParental
-	case filtering.FilteredSafeSearch:
-		e.Result = stats.RSafeSearch
-	case filtering.FilteredBlockList,
-		filtering.FilteredInvalid,
-		filtering.FilteredBlockedService:
-		e.Result = stats.RFiltered
+	switch qtype := pctx.Req.Question[0].Qtype; qtype {
+	case dns.TypeAXFR, dns.TypeIXFR:
+		// Zone transfers aren't filtered, and the filtering result isn't
+		// meaningful for them, so report the transfer itself instead of
+		// falling through to the filtering-reason switch below.
+		if ctx.zoneTransferRefused {
+			e.Result = stats.RZoneTransferRefused
+		} else {
+			e.Result = stats.RZoneTransfer
+		}
+	default:
+		switch res.Reason {
+		case filtering.FilteredSafeBrowsing:
+			e.Result = stats.RSafeBrowsing
+		case filtering.FilteredParental:
+			e.Result = stats.RParental
+		case filtering.FilteredSafeSearch:
+			e.Result = stats.RSafeSearch
+		case filtering.FilteredBlockList,
+			filtering.FilteredInvalid,
+			filtering.FilteredBlockedService:
+			e.Result = stats.RFiltered
+		}
 	}
 
 	s.stats.Update(e)
@@ -0,0 +1,151 @@
+package dnsforward
+
+import (
+	"net"
+	"time"
+
+	"github.com/AdguardTeam/golibs/log"
+	"github.com/AdguardTeam/golibs/netutil"
+	"github.com/miekg/dns"
+)
+
+const (
+	// zoneTransferReadTimeout bounds how long transferZone waits for the
+	// upstream to send the next envelope of a zone transfer before giving
+	// up, so a slow or hung upstream can't block the handling goroutine
+	// indefinitely.
+	zoneTransferReadTimeout = 30 * time.Second
+
+	// zoneTransferMaxBytes is the largest packed response transferZone will
+	// return, the maximum size of a DNS message over TCP (a 16-bit length
+	// prefix).  A real AXFR/IXFR is a multi-message exchange precisely
+	// because a zone doesn't have to fit in one message; since transferZone
+	// aggregates every envelope into a single reply, a zone that doesn't
+	// fit is answered with SERVFAIL rather than silently truncated.
+	zoneTransferMaxBytes = 65535
+)
+
+// processZoneTransfer detects AXFR/IXFR requests and answers them directly,
+// short-circuiting the rest of the processing chain.  It must run before
+// the request would otherwise be forwarded upstream.
+//
+// Per RFC 5936 and RFC 1995, zone transfers are TCP-only; UDP transfer
+// requests are always refused with FORMERR.  TCP transfer requests are only
+// served if both ServerConfig.AllowZoneTransfer is set and the client
+// matches ServerConfig.ZoneTransferACL; otherwise they're refused with
+// REFUSED.
+func (s *Server) processZoneTransfer(ctx *dnsContext) (rc resultCode) {
+	pctx := ctx.proxyCtx
+	req := pctx.Req
+
+	if len(req.Question) == 0 {
+		return resultCodeSuccess
+	}
+
+	qtype := req.Question[0].Qtype
+	if qtype != dns.TypeAXFR && qtype != dns.TypeIXFR {
+		return resultCodeSuccess
+	}
+
+	if _, isTCP := pctx.Addr.(*net.TCPAddr); !isTCP {
+		log.Debug("dnsforward: refusing zone transfer of %s over udp", req.Question[0].Name)
+		pctx.Res = s.genDNSError(req, dns.RcodeFormatError)
+		ctx.zoneTransferRefused = true
+
+		return resultCodeFinish
+	}
+
+	s.serverLock.RLock()
+	allowed := s.conf.AllowZoneTransfer
+	acl := s.conf.ZoneTransferACL
+	upstream := s.conf.ZoneTransferUpstream
+	s.serverLock.RUnlock()
+
+	ip, _ := netutil.IPAndPortFromAddr(pctx.Addr)
+	if !allowed || !ipMatchesACL(ip, acl) {
+		log.Debug("dnsforward: refusing zone transfer of %s from %s", req.Question[0].Name, ip)
+		pctx.Res = s.genDNSError(req, dns.RcodeRefused)
+		ctx.zoneTransferRefused = true
+
+		return resultCodeFinish
+	}
+
+	pctx.Res = s.transferZone(req, upstream)
+
+	return resultCodeFinish
+}
+
+// ipMatchesACL reports whether ip is contained in any of the CIDRs in acl.
+func ipMatchesACL(ip net.IP, acl []string) (ok bool) {
+	for _, cidr := range acl {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			log.Debug("dnsforward: zone transfer acl: invalid cidr %q: %s", cidr, err)
+
+			continue
+		}
+
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// transferZone performs req, an AXFR or IXFR query, against upstream and
+// aggregates every RR returned across the transfer's envelopes into a
+// single response message.  If the aggregated answer would exceed
+// zoneTransferMaxBytes packed, the reply is SERVFAIL instead of a silently
+// truncated answer: real zone transfers are multi-message precisely
+// because a zone doesn't have to fit in one reply, and this server only
+// ever returns one.
+func (s *Server) transferZone(req *dns.Msg, upstream string) (resp *dns.Msg) {
+	if upstream == "" {
+		return s.genDNSError(req, dns.RcodeServerFailure)
+	}
+
+	tr := &dns.Transfer{ReadTimeout: zoneTransferReadTimeout}
+
+	envelopes, err := tr.In(req, upstream)
+	if err != nil {
+		log.Debug("dnsforward: zone transfer from %s: %s", upstream, err)
+
+		return s.genDNSError(req, dns.RcodeServerFailure)
+	}
+
+	resp = new(dns.Msg)
+	resp.SetReply(req)
+
+	for env := range envelopes {
+		if env.Error != nil {
+			log.Debug("dnsforward: zone transfer envelope from %s: %s", upstream, env.Error)
+
+			return s.genDNSError(req, dns.RcodeServerFailure)
+		}
+
+		resp.Answer = append(resp.Answer, env.RR...)
+
+		if resp.Len() > zoneTransferMaxBytes {
+			log.Debug(
+				"dnsforward: zone transfer of %s from %s exceeds %d bytes, refusing",
+				req.Question[0].Name,
+				upstream,
+				zoneTransferMaxBytes,
+			)
+
+			return s.genDNSError(req, dns.RcodeServerFailure)
+		}
+	}
+
+	return resp
+}
+
+// genDNSError returns a reply to req with the given rcode and no answer
+// section.
+func (s *Server) genDNSError(req *dns.Msg, rcode int) (resp *dns.Msg) {
+	resp = new(dns.Msg)
+	resp.SetRcode(req, rcode)
+
+	return resp
+}
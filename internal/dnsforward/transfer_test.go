@@ -0,0 +1,94 @@
+package dnsforward
+
+import (
+	"net"
+	"testing"
+
+	"github.com/AdguardTeam/dnsproxy/proxy"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newZoneTransferCtx(qtype uint16, addr net.Addr) (ctx *dnsContext) {
+	req := new(dns.Msg)
+	req.SetQuestion(dns.Fqdn("example.com"), qtype)
+
+	return &dnsContext{proxyCtx: &proxy.DNSContext{Req: req, Addr: addr}}
+}
+
+func TestServer_processZoneTransfer_notAZoneTransfer(t *testing.T) {
+	s := &Server{}
+	ctx := newZoneTransferCtx(dns.TypeA, &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+
+	rc := s.processZoneTransfer(ctx)
+
+	assert.Equal(t, resultCodeSuccess, rc)
+	assert.Nil(t, ctx.proxyCtx.Res)
+	assert.False(t, ctx.zoneTransferRefused)
+}
+
+func TestServer_processZoneTransfer_udpRefused(t *testing.T) {
+	s := &Server{conf: ServerConfig{AllowZoneTransfer: true, ZoneTransferACL: []string{"0.0.0.0/0"}}}
+	ctx := newZoneTransferCtx(dns.TypeAXFR, &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+
+	rc := s.processZoneTransfer(ctx)
+
+	require.Equal(t, resultCodeFinish, rc)
+	assert.True(t, ctx.zoneTransferRefused)
+	require.NotNil(t, ctx.proxyCtx.Res)
+	assert.Equal(t, dns.RcodeFormatError, ctx.proxyCtx.Res.Rcode)
+}
+
+func TestServer_processZoneTransfer_tcpRefusedByDefault(t *testing.T) {
+	s := &Server{}
+	ctx := newZoneTransferCtx(dns.TypeAXFR, &net.TCPAddr{IP: net.ParseIP("192.0.2.1")})
+
+	rc := s.processZoneTransfer(ctx)
+
+	require.Equal(t, resultCodeFinish, rc)
+	assert.True(t, ctx.zoneTransferRefused)
+	require.NotNil(t, ctx.proxyCtx.Res)
+	assert.Equal(t, dns.RcodeRefused, ctx.proxyCtx.Res.Rcode)
+}
+
+func TestServer_processZoneTransfer_tcpRefusedByACL(t *testing.T) {
+	s := &Server{conf: ServerConfig{
+		AllowZoneTransfer: true,
+		ZoneTransferACL:   []string{"10.0.0.0/8"},
+	}}
+	ctx := newZoneTransferCtx(dns.TypeIXFR, &net.TCPAddr{IP: net.ParseIP("192.0.2.1")})
+
+	rc := s.processZoneTransfer(ctx)
+
+	require.Equal(t, resultCodeFinish, rc)
+	assert.True(t, ctx.zoneTransferRefused)
+	require.NotNil(t, ctx.proxyCtx.Res)
+	assert.Equal(t, dns.RcodeRefused, ctx.proxyCtx.Res.Rcode)
+}
+
+func TestServer_processZoneTransfer_tcpAllowedByACL(t *testing.T) {
+	s := &Server{conf: ServerConfig{
+		AllowZoneTransfer: true,
+		ZoneTransferACL:   []string{"192.0.2.0/24"},
+		// Deliberately no upstream: the transfer itself fails, but the ACL
+		// gate must have let it through rather than refusing outright.
+	}}
+	ctx := newZoneTransferCtx(dns.TypeAXFR, &net.TCPAddr{IP: net.ParseIP("192.0.2.1")})
+
+	rc := s.processZoneTransfer(ctx)
+
+	require.Equal(t, resultCodeFinish, rc)
+	assert.False(t, ctx.zoneTransferRefused)
+	require.NotNil(t, ctx.proxyCtx.Res)
+	assert.Equal(t, dns.RcodeServerFailure, ctx.proxyCtx.Res.Rcode)
+}
+
+func TestIPMatchesACL(t *testing.T) {
+	acl := []string{"10.0.0.0/8", "192.168.1.0/24", "not a cidr"}
+
+	assert.True(t, ipMatchesACL(net.ParseIP("10.1.2.3"), acl))
+	assert.True(t, ipMatchesACL(net.ParseIP("192.168.1.42"), acl))
+	assert.False(t, ipMatchesACL(net.ParseIP("8.8.8.8"), acl))
+	assert.False(t, ipMatchesACL(net.ParseIP("8.8.8.8"), nil))
+}
@@ -0,0 +1,64 @@
+package dnstap
+
+// Mode selects the framestream transport used to talk to the collector.
+type Mode int
+
+// Transport modes supported by a Tap.
+const (
+	// ModeUnidirectional streams frames without negotiating a session or
+	// waiting for the collector to acknowledge them.  This is what most
+	// dnstap collectors (dnstap-read, Clickhouse pipelines) expect.
+	ModeUnidirectional Mode = iota
+
+	// ModeBidirectional negotiates a framestream session, waiting for
+	// READY/ACCEPT/START control frames before streaming payload frames.
+	ModeBidirectional
+)
+
+// MessageType is a dnstap message type that can be selectively enabled.
+type MessageType int
+
+// Message types that Config.Types can filter on.
+const (
+	TypeClientQuery MessageType = iota
+	TypeClientResponse
+)
+
+// Config configures a Tap.
+type Config struct {
+	// Enabled, if false, makes New return a no-op Tap.
+	Enabled bool
+
+	// SocketPath is the address of the dnstap collector, for example
+	// "unix:///var/run/dnstap.sock" or "tcp://127.0.0.1:6000".
+	SocketPath string
+
+	// Mode selects the framestream transport.
+	Mode Mode
+
+	// Types is the set of message types to emit.  An empty Types emits
+	// everything.
+	Types []MessageType
+
+	// QueueSize is the number of pending messages buffered before new
+	// ones are dropped.  Zero uses DefaultQueueSize.
+	QueueSize int
+}
+
+// DefaultQueueSize is the QueueSize used when Config.QueueSize is zero.
+const DefaultQueueSize = 1000
+
+// typeEnabled reports whether t should be emitted given the configured filter.
+func (c *Config) typeEnabled(t MessageType) bool {
+	if len(c.Types) == 0 {
+		return true
+	}
+
+	for _, want := range c.Types {
+		if want == t {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,230 @@
+// Package dnstap implements a querylog.Tapper that streams DNS query and
+// response events to an external collector in the dnstap wire format
+// (https://dnstap.info), consumable by dnstap-read, Clickhouse dnstap
+// pipelines, and similar tooling.
+package dnstap
+
+import (
+	"net"
+	"net/url"
+	"sync/atomic"
+
+	"github.com/AdguardTeam/AdGuardHome/internal/querylog"
+	"github.com/AdguardTeam/golibs/log"
+	"github.com/dnstap/golang-dnstap"
+	framestream "github.com/farsightsec/golang-framestream"
+	"google.golang.org/protobuf/proto"
+)
+
+// Tap is a querylog.Tapper that streams events to a dnstap collector over a
+// unix socket or TCP framestream connection.  A Tap is safe for concurrent
+// use.
+//
+// Add never blocks: messages are handed off over a bounded channel, and if
+// the channel is full (the collector is slow or unreachable) the message is
+// dropped and counted in Dropped rather than stalling the caller.
+type Tap struct {
+	conf Config
+
+	queue  chan *dnstap.Message
+	closed chan struct{}
+
+	// dropped counts messages dropped because the queue was full.  It's
+	// read with Dropped and must only be accessed atomically.
+	dropped uint64
+}
+
+// New creates a Tap from conf.  If conf.Enabled is false, New returns a
+// no-op Tap whose Add does nothing.
+func New(conf Config) (t *Tap, err error) {
+	t = &Tap{conf: conf}
+	if !conf.Enabled {
+		return t, nil
+	}
+
+	queueSize := conf.QueueSize
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+
+	t.queue = make(chan *dnstap.Message, queueSize)
+	t.closed = make(chan struct{})
+
+	enc, err := t.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	go t.run(enc)
+
+	return t, nil
+}
+
+// Attach creates a Tap from conf and, on success, registers it as l's
+// Tapper, so every subsequent l.Add call also streams to the collector.
+// The caller is still responsible for calling Close on the returned Tap
+// during shutdown.
+func Attach(conf Config, l querylog.QueryLog) (t *Tap, err error) {
+	t, err = New(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	l.SetTapper(t)
+
+	return t, nil
+}
+
+// dial opens the framestream connection described by conf.SocketPath.
+func (t *Tap) dial() (enc *framestream.Encoder, err error) {
+	u, err := url.Parse(t.conf.SocketPath)
+	if err != nil {
+		return nil, err
+	}
+
+	network := "unix"
+	addr := u.Path
+	if u.Scheme == "tcp" {
+		network = "tcp"
+		addr = u.Host
+	}
+
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return framestream.NewEncoder(conn, &framestream.EncoderOptions{
+		ContentType:   []byte("protobuf:dnstap.Dnstap"),
+		Bidirectional: t.conf.Mode == ModeBidirectional,
+	})
+}
+
+// run drains the queue and writes frames to enc until Close is called.
+func (t *Tap) run(enc *framestream.Encoder) {
+	defer func() { _ = enc.Close() }()
+
+	for {
+		select {
+		case msg := <-t.queue:
+			dt := &dnstap.Dnstap{
+				Type:    dnstap.Dnstap_MESSAGE.Enum(),
+				Message: msg,
+			}
+
+			data, mErr := proto.Marshal(dt)
+			if mErr != nil {
+				log.Debug("dnstap: marshalling message: %s", mErr)
+
+				continue
+			}
+
+			if _, wErr := enc.Write(data); wErr != nil {
+				log.Debug("dnstap: writing frame: %s", wErr)
+			}
+		case <-t.closed:
+			return
+		}
+	}
+}
+
+// Close stops the writer goroutine and closes the collector connection.  It
+// is safe to call Close on a disabled Tap.
+func (t *Tap) Close() (err error) {
+	if !t.conf.Enabled {
+		return nil
+	}
+
+	close(t.closed)
+
+	return nil
+}
+
+// Dropped returns the number of messages dropped so far because the queue
+// was full.
+func (t *Tap) Dropped() (n uint64) {
+	return atomic.LoadUint64(&t.dropped)
+}
+
+// Add implements the querylog.Tapper interface for *Tap.
+func (t *Tap) Add(m querylog.TapMessage) {
+	if !t.conf.Enabled {
+		return
+	}
+
+	if m.Response == nil {
+		t.enqueue(m, dnstap.Message_CLIENT_QUERY, TypeClientQuery)
+	} else {
+		t.enqueue(m, dnstap.Message_CLIENT_RESPONSE, TypeClientResponse)
+	}
+}
+
+// enqueue builds a dnstap message of the given type from m and hands it off
+// to the writer goroutine, dropping it if the queue is full.
+func (t *Tap) enqueue(m querylog.TapMessage, typ dnstap.Message_Type, filterType MessageType) {
+	if !t.conf.typeEnabled(filterType) {
+		return
+	}
+
+	msg := &dnstap.Message{
+		Type:           typ.Enum(),
+		SocketFamily:   socketFamily(m.ClientIP).Enum(),
+		SocketProtocol: socketProtocol(m.ClientProto).Enum(),
+		QueryAddress:   m.ClientIP,
+	}
+
+	if q := m.Query; q != nil {
+		if data, err := q.Pack(); err == nil {
+			msg.QueryMessage = data
+			msg.QueryTimeSec = ptrUint64(uint64(m.QueryTime.Unix()))
+			msg.QueryTimeNsec = ptrUint32(uint32(m.QueryTime.Nanosecond()))
+		} else {
+			log.Debug("dnstap: packing query: %s", err)
+		}
+	}
+
+	if r := m.Response; r != nil {
+		if data, err := r.Pack(); err == nil {
+			msg.ResponseMessage = data
+			msg.ResponseTimeSec = ptrUint64(uint64(m.ResponseTime.Unix()))
+			msg.ResponseTimeNsec = ptrUint32(uint32(m.ResponseTime.Nanosecond()))
+			msg.ResponseAddress = m.ClientIP
+		} else {
+			log.Debug("dnstap: packing response: %s", err)
+		}
+	}
+
+	select {
+	case t.queue <- msg:
+	default:
+		atomic.AddUint64(&t.dropped, 1)
+	}
+}
+
+// socketFamily maps ip to the dnstap socket family it was sent over.
+func socketFamily(ip net.IP) dnstap.SocketFamily {
+	if ip.To4() != nil {
+		return dnstap.SocketFamily_INET
+	}
+
+	return dnstap.SocketFamily_INET6
+}
+
+// socketProtocol maps a querylog.ClientProto to the dnstap socket protocol.
+// The dnstap schema has no value for DNS-over-QUIC, so DoQ queries are
+// reported as UDP, the transport they actually run over.
+func socketProtocol(p querylog.ClientProto) dnstap.SocketProtocol {
+	switch p {
+	case querylog.ClientProtoPlainTCP:
+		return dnstap.SocketProtocol_TCP
+	case querylog.ClientProtoDoT:
+		return dnstap.SocketProtocol_DOT
+	case querylog.ClientProtoDoH:
+		return dnstap.SocketProtocol_DOH
+	default:
+		return dnstap.SocketProtocol_UDP
+	}
+}
+
+func ptrUint64(v uint64) *uint64 { return &v }
+func ptrUint32(v uint32) *uint32 { return &v }
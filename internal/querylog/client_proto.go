@@ -0,0 +1,11 @@
+package querylog
+
+// ClientProtoPlainUDP and ClientProtoPlainTCP distinguish plain
+// DNS-over-UDP and DNS-over-TCP requests, mirroring the other ClientProto
+// values.  Previously both were left as the empty ClientProto value, which
+// made it impossible to separate TCP fallback or truncation issues from
+// ordinary UDP traffic in the query log.
+const (
+	ClientProtoPlainUDP ClientProto = "udp"
+	ClientProtoPlainTCP ClientProto = "tcp"
+)
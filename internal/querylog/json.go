@@ -86,10 +86,11 @@ func (l *queryLog) entryToJSON(entry *logEntry, anonFunc aghnet.IPMutFunc) (json
 		jsonEntry["client_id"] = entry.ClientID
 	}
 
+	var opt *dns.OPT
 	if msg != nil {
 		jsonEntry["status"] = dns.RcodeToString[msg.Rcode]
 
-		opt := msg.IsEdns0()
+		opt = msg.IsEdns0()
 		dnssecOk := false
 		if opt != nil {
 			dnssecOk = opt.Do()
@@ -98,6 +99,10 @@ func (l *queryLog) entryToJSON(entry *logEntry, anonFunc aghnet.IPMutFunc) (json
 		jsonEntry["answer_dnssec"] = dnssecOk
 	}
 
+	if edns := ednsToMap(entry, opt); edns != nil {
+		jsonEntry["edns"] = edns
+	}
+
 	jsonEntry["rules"] = resultRulesToJSONRules(entry.Result.Rules)
 
 	if len(entry.Result.Rules) > 0 && len(entry.Result.Rules[0].Text) > 0 {
@@ -109,8 +114,13 @@ func (l *queryLog) entryToJSON(entry *logEntry, anonFunc aghnet.IPMutFunc) (json
 		jsonEntry["service_name"] = entry.Result.ServiceName
 	}
 
-	answers := answerToMap(msg)
-	if answers != nil {
+	var answers []*dnsAnswer
+	if entry.QType == dns.TypeAXFR || entry.QType == dns.TypeIXFR {
+		// Zone transfers are TCP-only, multi-message exchanges that can
+		// carry thousands of RRs, so record a summary instead of the full
+		// RR dump that answerToMap would otherwise produce.
+		jsonEntry["zone_transfer"] = zoneTransferToMap(msg, len(entry.Answer))
+	} else if answers = answerToMap(msg); answers != nil {
 		jsonEntry["answer"] = answers
 	}
 
@@ -130,6 +140,81 @@ func (l *queryLog) entryToJSON(entry *logEntry, anonFunc aghnet.IPMutFunc) (json
 	return jsonEntry
 }
 
+// ednsToMap builds the "edns" entry of a logged answer, reporting the ECS
+// network sent upstream and the one returned, whether a DNS Cookie sent in
+// the request round-tripped in the response, the advertised UDP buffer
+// size, and any Extended DNS Errors.  It returns nil if entry and opt carry
+// none of these.
+func ednsToMap(entry *logEntry, opt *dns.OPT) (edns jobject) {
+	if opt == nil && entry.ReqECS == "" && entry.ReqCookieClient == "" {
+		return nil
+	}
+
+	edns = jobject{}
+
+	if entry.ReqECS != "" {
+		edns["ecs_sent"] = entry.ReqECS
+	}
+
+	var respCookie string
+	var edeErrs []jobject
+	if opt != nil {
+		edns["udp_size"] = opt.UDPSize()
+
+		for _, o := range opt.Option {
+			switch o := o.(type) {
+			case *dns.EDNS0_SUBNET:
+				edns["ecs_received"] = fmt.Sprintf("%s/%d", o.Address, o.SourceNetmask)
+			case *dns.EDNS0_COOKIE:
+				respCookie = o.Cookie
+			case *dns.EDNS0_EDE:
+				edeErrs = append(edeErrs, jobject{
+					"code": o.InfoCode,
+					"text": o.ExtraText,
+				})
+			}
+		}
+	}
+
+	if entry.ReqCookieClient != "" {
+		edns["cookie"] = len(respCookie) >= 16 && respCookie[:16] == entry.ReqCookieClient
+	}
+
+	if len(edeErrs) > 0 {
+		edns["ede"] = edeErrs
+	}
+
+	return edns
+}
+
+// zoneTransferToMap builds a summary of an AXFR/IXFR response.  msg may be
+// nil if the answer couldn't be unpacked; size is the length of the packed
+// wire-format answer in bytes.
+func zoneTransferToMap(msg *dns.Msg, size int) (summary jobject) {
+	summary = jobject{
+		"rr_count": 0,
+		"bytes":    size,
+	}
+
+	if msg == nil {
+		return summary
+	}
+
+	summary["rr_count"] = len(msg.Answer)
+
+	serials := make([]uint32, 0, 2)
+	for _, rr := range msg.Answer {
+		if soa, ok := rr.(*dns.SOA); ok {
+			serials = append(serials, soa.Serial)
+		}
+	}
+	if len(serials) > 0 {
+		summary["soa_serial"] = serials
+	}
+
+	return summary
+}
+
 func resultRulesToJSONRules(rules []*filtering.ResultRule) (jsonRules []jobject) {
 	jsonRules = make([]jobject, len(rules))
 	for i, r := range rules {
@@ -0,0 +1,63 @@
+package querylog
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+func optWithCookie(cookie string) (opt *dns.OPT) {
+	opt = &dns.OPT{Hdr: dns.RR_Header{Name: ".", Rrtype: dns.TypeOPT}}
+	opt.Option = append(opt.Option, &dns.EDNS0_COOKIE{Cookie: cookie})
+
+	return opt
+}
+
+func TestEDNSToMap_cookieRoundTrip(t *testing.T) {
+	const clientCookie = "0011223344556677"
+
+	entry := &logEntry{ReqCookieClient: clientCookie}
+	opt := optWithCookie(clientCookie + "8899aabbccddeeff")
+
+	edns := ednsToMap(entry, opt)
+
+	matches, ok := edns["cookie"].(bool)
+	assert.True(t, ok)
+	assert.True(t, matches)
+}
+
+func TestEDNSToMap_cookieMismatch(t *testing.T) {
+	entry := &logEntry{ReqCookieClient: "0011223344556677"}
+	opt := optWithCookie("ffeeddccbbaa99887766554433221100")
+
+	edns := ednsToMap(entry, opt)
+
+	matches, ok := edns["cookie"].(bool)
+	assert.True(t, ok)
+	assert.False(t, matches)
+}
+
+func TestEDNSToMap_noCookieSent(t *testing.T) {
+	entry := &logEntry{}
+	opt := optWithCookie("8899aabbccddeeff0011223344556677")
+
+	edns := ednsToMap(entry, opt)
+
+	_, ok := edns["cookie"]
+	assert.False(t, ok)
+}
+
+func TestEDNSToMap_ede(t *testing.T) {
+	entry := &logEntry{}
+	opt := &dns.OPT{Hdr: dns.RR_Header{Name: ".", Rrtype: dns.TypeOPT}}
+	opt.Option = append(opt.Option, &dns.EDNS0_EDE{InfoCode: 22, ExtraText: "no reachable authority"})
+
+	edns := ednsToMap(entry, opt)
+
+	assert.NotNil(t, edns["ede"])
+}
+
+func TestEDNSToMap_nilWhenEmpty(t *testing.T) {
+	assert.Nil(t, ednsToMap(&logEntry{}, nil))
+}
@@ -0,0 +1,223 @@
+package querylog
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/AdguardTeam/AdGuardHome/internal/aghnet"
+	"github.com/AdguardTeam/AdGuardHome/internal/filtering"
+	"github.com/miekg/dns"
+)
+
+// QueryLog logs incoming DNS requests and their results.
+type QueryLog interface {
+	// Add appends a new log entry built from p.
+	Add(p AddParams)
+
+	// SetTapper sets the Tapper that subsequent Add calls forward events
+	// to.  A nil t disables tapping.
+	SetTapper(t Tapper)
+}
+
+// AddParams are the parameters passed to QueryLog.Add.
+type AddParams struct {
+	// Question is the request message.
+	Question *dns.Msg
+
+	// Answer is the response message, if any.
+	Answer *dns.Msg
+
+	// OrigAnswer is the original, pre-filtering response, packed, if the
+	// response was rewritten by filtering.
+	OrigAnswer []byte
+
+	// Result is the filtering result.
+	Result *filtering.Result
+
+	// Upstream is the address of the upstream server that resolved the
+	// request, if any.
+	Upstream string
+
+	// ClientIP is the client's address.
+	ClientIP net.IP
+
+	// ClientID is the ID of the persistent client, if any.
+	ClientID string
+
+	// ClientProto is the transport the request arrived over.
+	ClientProto ClientProto
+
+	// Elapsed is the time it took to process the request.
+	Elapsed time.Duration
+
+	// Cached reports whether the response came from the cache.
+	Cached bool
+}
+
+// logEntry is a single entry in the query log.
+type logEntry struct {
+	IP   net.IP
+	Time time.Time
+
+	QHost  string
+	QType  uint16
+	QClass uint16
+
+	ClientProto ClientProto
+	ClientID    string
+	client      interface{}
+
+	Answer     []byte
+	OrigAnswer []byte
+
+	Result  filtering.Result
+	Elapsed time.Duration
+	Cached  bool
+
+	Upstream string
+
+	// ReqECS is the ECS network sent upstream in the request, in CIDR
+	// notation, or empty if none was sent.
+	ReqECS string
+
+	// ReqCookieClient is the 16-character hex client part of the DNS
+	// Cookie option sent in the request, or empty if none was sent.
+	ReqCookieClient string
+
+	// EDECode is the Extended DNS Error code of the first EDE option in
+	// the response's OPT record, or nil if the response had none.
+	EDECode *uint16
+}
+
+// New creates a QueryLog with no Tapper attached.  Use SetTapper to start
+// streaming events to a collector once one is available.
+func New() (l QueryLog) {
+	return &queryLog{anonymizer: &aghnet.IPMutBox{}}
+}
+
+// queryLog is the default QueryLog implementation.
+type queryLog struct {
+	anonymizer *aghnet.IPMutBox
+
+	tapperMu sync.RWMutex
+
+	// tapper, if set, receives a TapMessage for every query and every
+	// response Add processes.  Access is guarded by tapperMu rather than mu
+	// so that SetTapper doesn't have to wait on whatever Add is doing with
+	// l.entries.
+	tapper Tapper
+
+	mu      sync.Mutex
+	entries []*logEntry
+}
+
+// SetTapper sets the Tapper that subsequent Add calls forward events to.  A
+// nil t disables tapping.  SetTapper is safe for concurrent use with Add.
+func (l *queryLog) SetTapper(t Tapper) {
+	l.tapperMu.Lock()
+	defer l.tapperMu.Unlock()
+
+	l.tapper = t
+}
+
+// Add implements the QueryLog interface for *queryLog.
+func (l *queryLog) Add(p AddParams) {
+	now := time.Now()
+
+	entry := &logEntry{
+		IP:          p.ClientIP,
+		Time:        now,
+		ClientProto: p.ClientProto,
+		ClientID:    p.ClientID,
+		OrigAnswer:  p.OrigAnswer,
+		Elapsed:     p.Elapsed,
+		Cached:      p.Cached,
+		Upstream:    p.Upstream,
+	}
+
+	if p.Result != nil {
+		entry.Result = *p.Result
+	}
+
+	if q := p.Question; q != nil && len(q.Question) > 0 {
+		question := q.Question[0]
+		entry.QHost = question.Name
+		entry.QType = question.Qtype
+		entry.QClass = question.Qclass
+	}
+
+	if q := p.Question; q != nil {
+		if opt := q.IsEdns0(); opt != nil {
+			for _, o := range opt.Option {
+				switch o := o.(type) {
+				case *dns.EDNS0_SUBNET:
+					entry.ReqECS = fmt.Sprintf("%s/%d", o.Address, o.SourceNetmask)
+				case *dns.EDNS0_COOKIE:
+					if len(o.Cookie) >= 16 {
+						entry.ReqCookieClient = o.Cookie[:16]
+					}
+				}
+			}
+		}
+	}
+
+	if a := p.Answer; a != nil {
+		if data, err := a.Pack(); err == nil {
+			entry.Answer = data
+		}
+
+		if opt := a.IsEdns0(); opt != nil {
+			for _, o := range opt.Option {
+				if ede, ok := o.(*dns.EDNS0_EDE); ok {
+					code := ede.InfoCode
+					entry.EDECode = &code
+
+					break
+				}
+			}
+		}
+	}
+
+	// Add runs once processing is done, so the request arrived p.Elapsed
+	// before now.
+	l.tap(p, now.Add(-p.Elapsed))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries = append(l.entries, entry)
+}
+
+// tap forwards p to l.tapper as a query event and, once the response is
+// known, a response event.  It's a no-op if no tapper is configured.
+func (l *queryLog) tap(p AddParams, queryTime time.Time) {
+	l.tapperMu.RLock()
+	tapper := l.tapper
+	l.tapperMu.RUnlock()
+
+	if tapper == nil {
+		return
+	}
+
+	tapper.Add(TapMessage{
+		Query:       p.Question,
+		QueryTime:   queryTime,
+		ClientProto: p.ClientProto,
+		ClientIP:    p.ClientIP,
+	})
+
+	if p.Answer == nil {
+		return
+	}
+
+	tapper.Add(TapMessage{
+		Query:        p.Question,
+		Response:     p.Answer,
+		QueryTime:    queryTime,
+		ResponseTime: queryTime.Add(p.Elapsed),
+		ClientProto:  p.ClientProto,
+		ClientIP:     p.ClientIP,
+	})
+}
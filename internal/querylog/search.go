@@ -0,0 +1,50 @@
+package querylog
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// searchCriteria holds the filters applied to a query log search.  A
+// zero-valued field matches every entry for that field.
+type searchCriteria struct {
+	// clientProto, if non-empty, restricts results to entries whose
+	// ClientProto matches it exactly, for example "udp" or "doh".
+	clientProto ClientProto
+
+	// edeCode, if set, restricts results to entries whose response
+	// carried an Extended DNS Error with this InfoCode, for example 22
+	// ("no reachable authority") for SERVFAILs caused by a dead upstream.
+	edeCode *uint16
+}
+
+// newSearchCriteria builds a searchCriteria from the parsed query string of
+// a search request, for example
+// GET /control/querylog?client_proto=doh&ede=22.
+func newSearchCriteria(q url.Values) (c *searchCriteria) {
+	c = &searchCriteria{
+		clientProto: ClientProto(q.Get("client_proto")),
+	}
+
+	if v := q.Get("ede"); v != "" {
+		if n, err := strconv.ParseUint(v, 10, 16); err == nil {
+			code := uint16(n)
+			c.edeCode = &code
+		}
+	}
+
+	return c
+}
+
+// match reports whether entry satisfies every filter in c.
+func (c *searchCriteria) match(entry *logEntry) (ok bool) {
+	if c.clientProto != "" && entry.ClientProto != c.clientProto {
+		return false
+	}
+
+	if c.edeCode != nil && (entry.EDECode == nil || *entry.EDECode != *c.edeCode) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,56 @@
+package querylog
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSearchCriteria(t *testing.T) {
+	q := url.Values{}
+	q.Set("client_proto", "tcp")
+	q.Set("ede", "22")
+
+	c := newSearchCriteria(q)
+
+	assert.Equal(t, ClientProtoPlainTCP, c.clientProto)
+	if assert.NotNil(t, c.edeCode) {
+		assert.EqualValues(t, 22, *c.edeCode)
+	}
+}
+
+func TestNewSearchCriteria_empty(t *testing.T) {
+	c := newSearchCriteria(url.Values{})
+
+	assert.Equal(t, ClientProto(""), c.clientProto)
+	assert.Nil(t, c.edeCode)
+}
+
+func TestNewSearchCriteria_invalidEDE(t *testing.T) {
+	q := url.Values{}
+	q.Set("ede", "not a number")
+
+	c := newSearchCriteria(q)
+
+	assert.Nil(t, c.edeCode)
+}
+
+func TestSearchCriteria_match(t *testing.T) {
+	code := uint16(22)
+	otherCode := uint16(3)
+
+	c := &searchCriteria{clientProto: ClientProtoPlainTCP, edeCode: &code}
+
+	assert.True(t, c.match(&logEntry{ClientProto: ClientProtoPlainTCP, EDECode: &code}))
+	assert.False(t, c.match(&logEntry{ClientProto: ClientProtoPlainUDP, EDECode: &code}))
+	assert.False(t, c.match(&logEntry{ClientProto: ClientProtoPlainTCP, EDECode: &otherCode}))
+	assert.False(t, c.match(&logEntry{ClientProto: ClientProtoPlainTCP}))
+}
+
+func TestSearchCriteria_match_empty(t *testing.T) {
+	c := &searchCriteria{}
+
+	assert.True(t, c.match(&logEntry{ClientProto: ClientProtoPlainUDP}))
+	assert.True(t, c.match(&logEntry{}))
+}
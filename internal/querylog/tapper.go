@@ -0,0 +1,41 @@
+package querylog
+
+import (
+	"net"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// TapMessage carries the fields needed to emit a single dnstap event.  It is
+// built from the same data as AddParams, but keeps the wire-format messages
+// and timestamps that a streaming sink needs and the JSON query log doesn't.
+type TapMessage struct {
+	// Query is the original request message.
+	Query *dns.Msg
+
+	// Response is the response message, or nil if none was produced.
+	Response *dns.Msg
+
+	// QueryTime is the moment the request was received.
+	QueryTime time.Time
+
+	// ResponseTime is the moment the response was produced.  It is the
+	// zero Time if there is no Response.
+	ResponseTime time.Time
+
+	// ClientProto is the transport the request arrived over.
+	ClientProto ClientProto
+
+	// ClientIP is the client's address.
+	ClientIP net.IP
+}
+
+// Tapper is implemented by sinks that mirror query log events to an
+// external collector, such as the dnstap subpackage.  Add must not block
+// the resolution path; implementations that can't keep up with incoming
+// messages should drop them and count the drops rather than stall the
+// caller.
+type Tapper interface {
+	Add(m TapMessage)
+}
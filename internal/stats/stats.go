@@ -0,0 +1,53 @@
+// Package stats implements the statistics accumulation used by the
+// dashboard: per-domain and per-client counters, bucketed by the result a
+// query produced.
+package stats
+
+// Result is the result category recorded for a single query.
+type Result int
+
+// Query result categories.
+const (
+	_ Result = iota
+	RNotFiltered
+	RFiltered
+	RSafeBrowsing
+	RSafeSearch
+	RParental
+
+	// RZoneTransfer is recorded for AXFR/IXFR queries that passed the UDP
+	// and ACL checks and were passed on to the upstream, including those
+	// whose transfer from the upstream then failed.  Zone transfers aren't
+	// subject to content filtering, so they don't fit any of the other
+	// categories.
+	RZoneTransfer
+
+	// RZoneTransferRefused is recorded for AXFR/IXFR queries that were
+	// turned down, whether for arriving over UDP or for failing the
+	// zone-transfer ACL.  Together with RZoneTransfer, it lets operators
+	// see both transfer volume and refused-transfer counts on the
+	// dashboard.
+	RZoneTransferRefused
+)
+
+// Entry is a single statistics data point for one query.
+type Entry struct {
+	// Domain is the lowercased, FQDN-without-trailing-dot question name.
+	Domain string
+
+	// Client is the client ID if known, otherwise the client's IP address.
+	Client string
+
+	// Time is the processing time, in microseconds.
+	Time uint32
+
+	// Result is the result category of the query.
+	Result Result
+}
+
+// Interface updates the statistics store with data about processed
+// queries.
+type Interface interface {
+	// Update records e.
+	Update(e Entry)
+}